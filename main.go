@@ -0,0 +1,178 @@
+// Command glass serves Voronoi diagrams generated on demand over HTTP.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kurin/glass/render"
+	"github.com/kurin/glass/scene"
+)
+
+var (
+	numPoints = flag.Int("num_points", render.DefaultNumPoints, "default number of points")
+	seed      = flag.Int64("seed", time.Now().UnixNano(), "default rng seed")
+	metric    = flag.String("metric", "euclidean", "default distance metric: euclidean, rtree, manhattan, chebyshev, minkowski, greatcircle")
+	addr      = flag.String("addr", ":8822", "http listen address")
+	savePath  = flag.String("save", "", "save the default scene to this path, then exit without serving")
+	loadPath  = flag.String("load", "", "load the default scene from this path instead of generating one")
+	aa        = flag.Bool("aa", false, "anti-alias cell borders")
+)
+
+const thumbSize = 128
+
+func main() {
+	flag.Parse()
+
+	defaultOpts := render.Options{NumPoints: *numPoints, Seed: *seed, Metric: *metric, AA: *aa}
+	dfltScene, err := resolveDefaultScene(defaultOpts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	render.Cache(dfltScene.Opts, dfltScene)
+
+	if *savePath != "" {
+		if err := saveScene(*savePath, dfltScene); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("saved scene to", *savePath)
+		return
+	}
+
+	http.HandleFunc("/voronoi.png", handlePNG)
+	http.HandleFunc("/voronoi.svg", handleSVG)
+	http.HandleFunc("/voronoi.json", handleJSON)
+	http.HandleFunc("/thumb/", handleThumb)
+
+	fmt.Println("listening on", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// resolveDefaultScene returns the scene requests default to: loaded
+// from -load if given (skipping the O(n^2) bisector pass), else
+// generated from opts.
+func resolveDefaultScene(opts render.Options) (*render.Scene, error) {
+	if *loadPath == "" {
+		return render.Generate(opts), nil
+	}
+	f, err := os.Open(*loadPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return scene.Load(f)
+}
+
+func saveScene(path string, s *render.Scene) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := scene.Save(f, s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// optsFromQuery builds render.Options from query parameters, falling
+// back to the process's default flags for anything unset.
+func optsFromQuery(q url.Values) render.Options {
+	opts := render.Options{
+		NumPoints: *numPoints,
+		Seed:      *seed,
+		Metric:    *metric,
+		AA:        *aa,
+	}
+	if v, err := strconv.Atoi(q.Get("points")); err == nil {
+		opts.NumPoints = v
+	}
+	if v, err := strconv.ParseInt(q.Get("seed"), 10, 64); err == nil {
+		opts.Seed = v
+	}
+	if v, err := strconv.Atoi(q.Get("w")); err == nil {
+		opts.Width = v
+	}
+	if v, err := strconv.Atoi(q.Get("h")); err == nil {
+		opts.Height = v
+	}
+	if v := q.Get("metric"); v != "" {
+		opts.Metric = v
+	}
+	if v, err := strconv.ParseFloat(q.Get("minkowski_p"), 64); err == nil {
+		opts.MinkowskiP = v
+	}
+	if v, err := strconv.ParseBool(q.Get("grid")); err == nil {
+		opts.Grid = v
+	}
+	if v := q.Get("jitter"); v != "" {
+		opts.Jitter = v
+	}
+	if v, err := strconv.ParseBool(q.Get("aa")); err == nil {
+		opts.AA = v
+	}
+	return opts
+}
+
+// handlePNG serves GET /voronoi.png?seed=...&points=...&w=...&h=...
+func handlePNG(w http.ResponseWriter, r *http.Request) {
+	scene := render.GetOrGenerate(optsFromQuery(r.URL.Query()))
+	w.Header().Set("Content-Type", "image/png")
+	if err := png.Encode(w, scene.Img); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleSVG serves GET /voronoi.svg, emitting real vector edges from the
+// scene's adjacency list.
+func handleSVG(w http.ResponseWriter, r *http.Request) {
+	scene := render.GetOrGenerate(optsFromQuery(r.URL.Query()))
+	w.Header().Set("Content-Type", "image/svg+xml")
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, scene.Opts.Width, scene.Opts.Height)
+	for _, e := range scene.Edges() {
+		fmt.Fprintf(w, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black"/>`, e.X1, e.Y1, e.X2, e.Y2)
+	}
+	fmt.Fprint(w, `</svg>`)
+}
+
+// handleJSON serves GET /voronoi.json: site coordinates, adjacency
+// edges, and per-cell color for client-side rendering.
+func handleJSON(w http.ResponseWriter, r *http.Request) {
+	scene := render.GetOrGenerate(optsFromQuery(r.URL.Query()))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Sites []render.Site `json:"sites"`
+		Edges []render.Edge `json:"edges"`
+	}{scene.Sites(), scene.Edges()})
+}
+
+// handleThumb serves GET /thumb/{seed}, rendering and downscaling to a
+// fixed size before PNG-encoding.
+func handleThumb(w http.ResponseWriter, r *http.Request) {
+	seedVal, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/thumb/"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad seed", http.StatusBadRequest)
+		return
+	}
+	opts := optsFromQuery(r.URL.Query())
+	opts.Seed = seedVal
+	scene := render.GetOrGenerate(opts)
+	thumb := render.Resize(scene.Img, thumbSize, thumbSize)
+
+	w.Header().Set("Content-Type", "image/png")
+	if err := png.Encode(w, thumb); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"container/list"
+	"sync"
+)
+
+// sceneCache is a fixed-capacity LRU cache of Scenes keyed by
+// Options.Key(), so repeated requests for the same scene skip the
+// O(n^2) bisector sweep.
+type sceneCache struct {
+	capacity int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key   string
+	scene *Scene
+}
+
+func newSceneCache(capacity int) *sceneCache {
+	return &sceneCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *sceneCache) get(key string) (*Scene, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).scene, true
+}
+
+func (c *sceneCache) put(key string, s *Scene) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*cacheEntry).scene = s
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, scene: s})
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// DefaultCacheSize is the capacity of the package-level scene cache used
+// by GetOrGenerate.
+const DefaultCacheSize = 64
+
+var cache = newSceneCache(DefaultCacheSize)
+
+// GetOrGenerate returns the cached Scene for opts if one is present, and
+// otherwise generates one, caches it, and returns it.
+func GetOrGenerate(opts Options) *Scene {
+	key := opts.Key()
+	if s, ok := cache.get(key); ok {
+		return s
+	}
+	s := Generate(opts)
+	cache.put(key, s)
+	return s
+}
+
+// Cache inserts s into the package-level scene cache under opts.Key(),
+// for prepopulating it with a scene obtained some other way than
+// Generate (for instance, one loaded from disk).
+func Cache(opts Options, s *Scene) {
+	cache.put(opts.Key(), s)
+}
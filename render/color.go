@@ -0,0 +1,272 @@
+package render
+
+import (
+	"image/color"
+	"math/rand"
+	"sort"
+)
+
+// colorPoints assigns each site a color from palette (or DefaultPalette,
+// if palette is empty) such that no two adjacent sites share one, using
+// rng to vary the result across seeds without affecting correctness.
+func colorPoints(points []*point, al *adjList, palette []color.Color, rng *rand.Rand) {
+	if len(palette) == 0 {
+		palette = append([]color.Color(nil), DefaultPalette...)
+	}
+
+	idxOf := make(map[xy]int, len(points))
+	for i, p := range points {
+		idxOf[p.xy()] = i
+	}
+	adj := make([][]int, len(points))
+	for a, nbrs := range al.l {
+		ai, ok := idxOf[a]
+		if !ok {
+			continue
+		}
+		for b := range nbrs {
+			if bi, ok := idxOf[b]; ok {
+				adj[ai] = append(adj[ai], bi)
+			}
+		}
+	}
+
+	colorOf := colorGraph(len(points), adj, len(palette), rng)
+	for i, p := range points {
+		p.color = palette[colorOf[i]]
+	}
+}
+
+// colorGraph assigns each of n vertices (0..n-1, with adjacency adj) a
+// palette index in [0,k), such that no two adjacent vertices share one.
+// It orders vertices by DSatur (maximum saturation degree, ties broken
+// by original degree, further ties broken via rng), assigns the
+// lowest-indexed available color, and repairs a vertex with no
+// available color via Kempe-chain recoloring. Since glass's adjacency
+// graphs are planar, the four-color theorem guarantees a valid
+// coloring exists for any k>=4, so the combination of DSatur and Kempe
+// chains should always succeed; a bounded backtracking search is kept
+// as a fallback in case it doesn't, and a non-backtracking greedy
+// assignment (which may leave conflicts) is the final fallback so this
+// never panics or hangs.
+func colorGraph(n int, adj [][]int, k int, rng *rand.Rand) []int {
+	if colorOf, ok := dsaturColor(n, adj, k, rng); ok {
+		return colorOf
+	}
+	if colorOf, ok := backtrackColor(n, adj, k); ok {
+		return colorOf
+	}
+	colorOf := make([]int, n)
+	for v := range colorOf {
+		colorOf[v] = v % k
+	}
+	return colorOf
+}
+
+func dsaturColor(n int, adj [][]int, k int, rng *rand.Rand) ([]int, bool) {
+	colorOf := make([]int, n)
+	for i := range colorOf {
+		colorOf[i] = -1
+	}
+	for remaining := n; remaining > 0; remaining-- {
+		v := pickDSaturVertex(n, adj, colorOf, rng)
+		c, ok := lowestAvailable(v, adj, colorOf, k)
+		if !ok && kempeChainRescue(v, adj, colorOf, k) {
+			c, ok = lowestAvailable(v, adj, colorOf, k)
+		}
+		if !ok {
+			return colorOf, false
+		}
+		colorOf[v] = c
+	}
+	return colorOf, true
+}
+
+// pickDSaturVertex returns the uncolored vertex with the highest
+// saturation degree (number of distinct colors among colored
+// neighbors), breaking ties by original degree and then by rng.
+func pickDSaturVertex(n int, adj [][]int, colorOf []int, rng *rand.Rand) int {
+	best, bestSat, bestDeg := -1, -1, -1
+	var ties []int
+	for v := 0; v < n; v++ {
+		if colorOf[v] != -1 {
+			continue
+		}
+		sat, deg := saturationDegree(v, adj, colorOf), len(adj[v])
+		switch {
+		case sat > bestSat || (sat == bestSat && deg > bestDeg):
+			best, bestSat, bestDeg = v, sat, deg
+			ties = []int{v}
+		case sat == bestSat && deg == bestDeg:
+			ties = append(ties, v)
+		}
+	}
+	if rng != nil && len(ties) > 1 {
+		return ties[rng.Intn(len(ties))]
+	}
+	return best
+}
+
+func saturationDegree(v int, adj [][]int, colorOf []int) int {
+	seen := map[int]bool{}
+	for _, u := range adj[v] {
+		if colorOf[u] != -1 {
+			seen[colorOf[u]] = true
+		}
+	}
+	return len(seen)
+}
+
+func lowestAvailable(v int, adj [][]int, colorOf []int, k int) (int, bool) {
+	used := make([]bool, k)
+	for _, u := range adj[v] {
+		if c := colorOf[u]; c >= 0 && c < k {
+			used[c] = true
+		}
+	}
+	for c := 0; c < k; c++ {
+		if !used[c] {
+			return c, true
+		}
+	}
+	return -1, false
+}
+
+// kempeChainRescue tries to free a color for v, which has no available
+// color among v's colored neighbors, by finding two colors ca, cb used
+// by v's neighbors and swapping them throughout the Kempe chain (the
+// connected component of the subgraph induced by colors ca and cb)
+// reachable from one of v's ca-colored neighbors. The swap frees ca at
+// v unless that chain also reaches a cb-colored neighbor of v, in which
+// case it's tried for the next pair of colors.
+func kempeChainRescue(v int, adj [][]int, colorOf []int, k int) bool {
+	seen := map[int]bool{}
+	var used []int
+	for _, u := range adj[v] {
+		if c := colorOf[u]; c >= 0 && !seen[c] {
+			seen[c] = true
+			used = append(used, c)
+		}
+	}
+	sort.Ints(used)
+	for i := 0; i < len(used); i++ {
+		for j := i + 1; j < len(used); j++ {
+			if swapKempeChain(v, adj, colorOf, used[i], used[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func swapKempeChain(v int, adj [][]int, colorOf []int, ca, cb int) bool {
+	start := -1
+	for _, u := range adj[v] {
+		if colorOf[u] == ca {
+			start = u
+			break
+		}
+	}
+	if start == -1 {
+		return false
+	}
+	component := kempeComponent(start, adj, colorOf, ca, cb)
+	for _, u := range adj[v] {
+		if colorOf[u] == cb && component[u] {
+			return false
+		}
+	}
+	for u := range component {
+		if colorOf[u] == ca {
+			colorOf[u] = cb
+		} else {
+			colorOf[u] = ca
+		}
+	}
+	return true
+}
+
+// kempeComponent returns the vertices reachable from start by only
+// passing through vertices colored ca or cb.
+func kempeComponent(start int, adj [][]int, colorOf []int, ca, cb int) map[int]bool {
+	seen := map[int]bool{start: true}
+	queue := []int{start}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, w := range adj[u] {
+			if seen[w] {
+				continue
+			}
+			if colorOf[w] == ca || colorOf[w] == cb {
+				seen[w] = true
+				queue = append(queue, w)
+			}
+		}
+	}
+	return seen
+}
+
+// backtrackBudget bounds the number of color trials backtrackColor will
+// attempt before giving up.
+const backtrackBudget = 200000
+
+// backtrackColor runs a bounded backtracking search for a valid
+// k-coloring, in a fixed descending-degree vertex order. It's a last
+// resort for when dsaturColor gets stuck.
+func backtrackColor(n int, adj [][]int, k int) ([]int, bool) {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return len(adj[order[i]]) > len(adj[order[j]]) })
+
+	colorOf := make([]int, n)
+	for i := range colorOf {
+		colorOf[i] = -1
+	}
+	budget := backtrackBudget
+	if backtrackStep(0, order, adj, colorOf, k, &budget) {
+		return colorOf, true
+	}
+	return colorOf, false
+}
+
+func backtrackStep(i int, order []int, adj [][]int, colorOf []int, k int, budget *int) bool {
+	if i == len(order) {
+		return true
+	}
+	v := order[i]
+	for c := 0; c < k; c++ {
+		if *budget--; *budget < 0 {
+			return false
+		}
+		if colorAvailable(v, adj, colorOf, c) {
+			colorOf[v] = c
+			if backtrackStep(i+1, order, adj, colorOf, k, budget) {
+				return true
+			}
+			colorOf[v] = -1
+		}
+	}
+	return false
+}
+
+func colorAvailable(v int, adj [][]int, colorOf []int, c int) bool {
+	for _, u := range adj[v] {
+		if colorOf[u] == c {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultPalette is used when Options.Palette is empty.
+var DefaultPalette = []color.Color{
+	color.RGBA{155, 17, 30, 255},
+	color.RGBA{190, 83, 28, 255},
+	color.RGBA{241, 196, 0, 255},
+	color.RGBA{19, 104, 67, 255},
+	color.RGBA{135, 206, 235, 255},
+	color.RGBA{89, 49, 95, 255},
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// assertProperColoring fails the test if any adjacent pair of vertices
+// shares a color.
+func assertProperColoring(t *testing.T, adj [][]int, colorOf []int) {
+	t.Helper()
+	for v, nbrs := range adj {
+		for _, u := range nbrs {
+			if colorOf[v] == colorOf[u] {
+				t.Errorf("vertices %d and %d are adjacent but both colored %d", v, u, colorOf[v])
+			}
+		}
+	}
+}
+
+func symmetric(n int, edges [][2]int) [][]int {
+	adj := make([][]int, n)
+	for _, e := range edges {
+		adj[e[0]] = append(adj[e[0]], e[1])
+		adj[e[1]] = append(adj[e[1]], e[0])
+	}
+	return adj
+}
+
+func TestColorGraphK4(t *testing.T) {
+	// K4: every vertex adjacent to every other; needs exactly 4 colors.
+	adj := symmetric(4, [][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}})
+	colorOf := colorGraph(4, adj, 4, rand.New(rand.NewSource(1)))
+	assertProperColoring(t, adj, colorOf)
+}
+
+func TestColorGraphWheel(t *testing.T) {
+	// Wheel with a 5-cycle rim (odd cycle, so the rim alone needs 3
+	// colors) plus a hub adjacent to every rim vertex, needing a 4th.
+	const rim = 5
+	var edges [][2]int
+	hub := rim
+	for i := 0; i < rim; i++ {
+		edges = append(edges, [2]int{i, (i + 1) % rim})
+		edges = append(edges, [2]int{hub, i})
+	}
+	adj := symmetric(rim+1, edges)
+	colorOf := colorGraph(rim+1, adj, len(DefaultPalette), rand.New(rand.NewSource(2)))
+	assertProperColoring(t, adj, colorOf)
+}
+
+func TestColorGraphPlanarTriangulation(t *testing.T) {
+	// The octahedron graph: 6 vertices, each adjacent to every other
+	// except its antipodal vertex. A small planar triangulation with
+	// chromatic number 3.
+	edges := [][2]int{
+		{0, 2}, {0, 3}, {0, 4}, {0, 5},
+		{1, 2}, {1, 3}, {1, 4}, {1, 5},
+		{2, 4}, {2, 5},
+		{3, 4}, {3, 5},
+	}
+	adj := symmetric(6, edges)
+	colorOf := colorGraph(6, adj, len(DefaultPalette), rand.New(rand.NewSource(3)))
+	assertProperColoring(t, adj, colorOf)
+}
+
+func TestColorGraphNoPanicOnTightPalette(t *testing.T) {
+	// Exactly as many colors as the chromatic number: DSatur plus Kempe
+	// chains must succeed without falling through to backtracking.
+	adj := symmetric(4, [][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}})
+	colorOf := colorGraph(4, adj, 4, rand.New(rand.NewSource(4)))
+	assertProperColoring(t, adj, colorOf)
+}
+
+func TestColorPointsAssignsDistinctNeighborColors(t *testing.T) {
+	points := []*point{
+		{vec: []float64{0, 0}},
+		{vec: []float64{1, 0}},
+		{vec: []float64{0, 1}},
+		{vec: []float64{1, 1}},
+	}
+	al := newAdjList()
+	al.link(points[0], points[1])
+	al.link(points[0], points[2])
+	al.link(points[1], points[3])
+	al.link(points[2], points[3])
+	al.link(points[0], points[3]) // diagonal: makes this K4-ish (minus one edge)
+
+	colorPoints(points, al, nil, rand.New(rand.NewSource(5)))
+
+	for a, nbrs := range al.l {
+		for b := range nbrs {
+			var ca, cb color.Color
+			for _, p := range points {
+				if p.xy() == a {
+					ca = p.color
+				}
+				if p.xy() == b {
+					cb = p.color
+				}
+			}
+			if ca == cb {
+				t.Errorf("adjacent sites %v and %v share color %v", a, b, ca)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"testing"
+)
+
+// knnPerPixel mimics the pre-R-tree drawNear: a full maxX-by-maxY sweep
+// of the image, calling idx.KNN(n, 2) at every pixel with no cached
+// neighborhood and no clipping to where the line actually falls.
+func knnPerPixel(l line, idx SpatialIndex, al *adjList) {
+	for x := 0; x < benchW; x++ {
+		y := -(l.a/l.b)*float64(x) + l.c/l.b
+		n := &point{vec: []float64{float64(x), y}}
+		nns := idx.KNN(n, 2)
+		al.link(nns[0], nns[1])
+	}
+	for y := 0; y < benchH; y++ {
+		x := -(l.b/l.a)*float64(y) + l.c/l.a
+		n := &point{vec: []float64{x, float64(y)}}
+		nns := idx.KNN(n, 2)
+		al.link(nns[0], nns[1])
+	}
+}
+
+func benchBisectors(points []*point) []line {
+	var lines []line
+	for i, p := range points {
+		for _, nn := range points[i+1:] {
+			lines = append(lines, p.biscector(nn))
+		}
+	}
+	return lines
+}
+
+// BenchmarkDrawNearKNNPerPixel and BenchmarkDrawNearRangeQuery compare the
+// per-pixel KNN sweep against drawNear's cached range-query sweep at the
+// point counts glass renders at in practice.
+func BenchmarkDrawNearKNNPerPixel(b *testing.B) {
+	for _, n := range []int{200, 2000} {
+		b.Run("n="+strconv.Itoa(n), func(b *testing.B) {
+			points := benchPoints(n)
+			idx := newKDIndex(points)
+			lines := benchBisectors(points)
+			al := &adjList{l: make(map[xy]map[xy]bool)}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				knnPerPixel(lines[i%len(lines)], idx, al)
+			}
+		})
+	}
+}
+
+func BenchmarkDrawNearRangeQuery(b *testing.B) {
+	for _, n := range []int{200, 2000} {
+		b.Run("n="+strconv.Itoa(n), func(b *testing.B) {
+			points := benchPoints(n)
+			idx := NewRTree(points)
+			lines := benchBisectors(points)
+			al := &adjList{l: make(map[xy]map[xy]bool)}
+			img := image.NewRGBA(image.Rect(0, 0, benchW, benchH))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lines[i%len(lines)].drawNear(img, idx, benchW, benchH, color.RGBA{0, 0, 0, 255}, al, false)
+			}
+		})
+	}
+}
+
+// BenchmarkDrawNearAA measures the cost of antialiased border blending
+// against the flat-set path at the same point counts.
+func BenchmarkDrawNearAA(b *testing.B) {
+	for _, n := range []int{200, 2000} {
+		b.Run("n="+strconv.Itoa(n), func(b *testing.B) {
+			points := benchPoints(n)
+			idx := NewRTree(points)
+			lines := benchBisectors(points)
+			al := &adjList{l: make(map[xy]map[xy]bool)}
+			img := image.NewRGBA(image.Rect(0, 0, benchW, benchH))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lines[i%len(lines)].drawNear(img, idx, benchW, benchH, color.RGBA{0, 0, 0, 255}, al, true)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"image/color"
+	"reflect"
+
+	kdtree "github.com/hongshibao/go-kdtree"
+)
+
+type line struct {
+	a, b, c float64
+}
+
+type point struct {
+	vec   []float64
+	color color.Color
+}
+
+type img interface {
+	Set(int, int, color.Color)
+}
+
+func (p *point) draw(im img, c color.Color) {
+	im.Set(int(p.vec[0]), int(p.vec[1]), c)
+}
+
+func (p *point) isIn(ps []kdtree.Point) bool {
+	for _, pp := range ps {
+		if reflect.DeepEqual(p, pp) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *point) line(o *point) line {
+	return line{
+		a: p.vec[1] - o.vec[1],                   // y0 - y1
+		b: o.vec[0] - p.vec[0],                   // x1 - x0
+		c: p.vec[1]*o.vec[0] - o.vec[1]*p.vec[0], // y0x1 - y1x0
+	}
+}
+
+func (l line) perp(p *point) line {
+	return line{
+		a: l.b,
+		b: -l.a,
+		c: l.b*p.vec[0] - l.a*p.vec[1],
+	}
+}
+
+func (p *point) biscector(o *point) line {
+	var mps []float64
+	for i := 0; i < p.Dim(); i++ {
+		mps = append(mps, (p.vec[i]+o.vec[i])/2)
+	}
+	mp := &point{vec: mps}
+	return p.line(o).perp(mp)
+}
+
+func (p *point) xy() xy {
+	return xy{x: p.vec[0], y: p.vec[1]}
+}
+
+type xy struct{ x, y float64 }
+
+func (p xy) point() *point { return &point{vec: []float64{p.x, p.y}} }
+
+type adjList struct {
+	l map[xy]map[xy]bool
+}
+
+func newAdjList() *adjList {
+	return &adjList{l: make(map[xy]map[xy]bool)}
+}
+
+func (l *adjList) link(a, b *point) {
+	l.add(a, b)
+	l.add(b, a)
+}
+
+func (l *adjList) add(a, b *point) {
+	if l.l[a.xy()] == nil {
+		l.l[a.xy()] = make(map[xy]bool)
+	}
+	l.l[a.xy()][b.xy()] = true
+}
+
+func (p *point) Dim() int               { return len(p.vec) }
+func (p *point) GetValue(d int) float64 { return p.vec[d] }
+
+func (p *point) Distance(o kdtree.Point) float64 {
+	var ret float64
+	for i := 0; i < p.Dim(); i++ {
+		tmp := p.GetValue(i) - o.GetValue(i)
+		ret += tmp * tmp
+	}
+	return ret
+}
+
+func (p *point) PlaneDistance(val float64, dim int) float64 {
+	tmp := p.GetValue(dim) - val
+	return tmp * tmp
+}
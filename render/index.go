@@ -0,0 +1,82 @@
+package render
+
+import kdtree "github.com/hongshibao/go-kdtree"
+
+// SpatialIndex is a nearest-neighbor index over site points. drawNear and
+// the coloring passes query one of these rather than depending on a
+// specific tree implementation, so the chosen Metric/-index decides which
+// concrete index backs them.
+type SpatialIndex interface {
+	KNN(p *point, k int) []*point
+	// RangeQuery returns every indexed point within radius of center.
+	RangeQuery(center *point, radius float64) []*point
+	// Metric returns the distance function this index orders candidates
+	// by, so callers that need to rank results themselves (rather than
+	// trust KNN/RangeQuery's own ordering) stay consistent with it.
+	Metric() Metric
+}
+
+// kdIndex adapts go-kdtree's tree, which always uses squared Euclidean
+// distance, to SpatialIndex.
+type kdIndex struct {
+	t *kdtree.KDTree
+}
+
+func newKDIndex(points []*point) *kdIndex {
+	kps := make([]kdtree.Point, len(points))
+	for i, p := range points {
+		kps[i] = p
+	}
+	return &kdIndex{t: kdtree.NewKDTree(kps)}
+}
+
+func (k *kdIndex) KNN(p *point, n int) []*point {
+	res := k.t.KNN(p, n)
+	out := make([]*point, len(res))
+	for i, r := range res {
+		out[i] = r.(*point)
+	}
+	return out
+}
+
+func (k *kdIndex) Metric() Metric { return EuclideanMetric{} }
+
+// kdRangeBatch bounds how many neighbors kdIndex.RangeQuery pulls before
+// filtering by radius: go-kdtree has no native range query, so this is an
+// approximation that can miss points if more than this many sites fall
+// within radius of center.
+const kdRangeBatch = 16
+
+func (k *kdIndex) RangeQuery(center *point, radius float64) []*point {
+	res := k.t.KNN(center, kdRangeBatch)
+	r2 := radius * radius
+	out := make([]*point, 0, len(res))
+	for _, r := range res {
+		p := r.(*point)
+		if center.Distance(p) <= r2 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// buildIndex constructs the SpatialIndex for the requested metric/index
+// name, falling back to the kd-tree's native squared Euclidean distance.
+func buildIndex(points []*point, metric string, minkowskiP float64) SpatialIndex {
+	switch metric {
+	case "euclidean", "":
+		return newKDIndex(points)
+	case "rtree":
+		return NewRTree(points)
+	case "manhattan":
+		return NewVPTree(points, ManhattanMetric{})
+	case "chebyshev":
+		return NewVPTree(points, ChebyshevMetric{})
+	case "minkowski":
+		return NewVPTree(points, MinkowskiMetric{P: minkowskiP})
+	case "greatcircle":
+		return NewVPTree(points, GreatCircleMetric{Radius: 6371})
+	default:
+		return newKDIndex(points)
+	}
+}
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+const (
+	benchW = 58 * 40
+	benchH = 20 * 40
+)
+
+func benchPoints(n int) []*point {
+	r := rand.New(rand.NewSource(1))
+	pts := make([]*point, n)
+	for i := range pts {
+		pts[i] = &point{vec: []float64{r.Float64() * benchW, r.Float64() * benchH}}
+	}
+	return pts
+}
+
+func benchmarkKNN(b *testing.B, idx SpatialIndex, queries []*point) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.KNN(queries[i%len(queries)], 2)
+	}
+}
+
+// BenchmarkKDTreeKNN and BenchmarkVPTreeKNN compare the two SpatialIndex
+// backends across the point-count range glass is actually used at.
+func BenchmarkKDTreeKNN(b *testing.B) {
+	for _, n := range []int{20, 200, 2000} {
+		b.Run("n="+strconv.Itoa(n), func(b *testing.B) {
+			points := benchPoints(n)
+			idx := newKDIndex(points)
+			benchmarkKNN(b, idx, points)
+		})
+	}
+}
+
+func BenchmarkVPTreeKNN(b *testing.B) {
+	for _, n := range []int{20, 200, 2000} {
+		b.Run("n="+strconv.Itoa(n), func(b *testing.B) {
+			points := benchPoints(n)
+			idx := NewVPTree(points, EuclideanMetric{})
+			benchmarkKNN(b, idx, points)
+		})
+	}
+}
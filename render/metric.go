@@ -0,0 +1,135 @@
+package render
+
+import "math"
+
+// Box is an axis-aligned bounding box, used by spatial indexes to decide
+// whether a subtree can possibly hold a point nearer than the current
+// best candidate.
+type Box struct {
+	Lo, Hi []float64
+}
+
+// Metric computes distances between points in R^n. Distance need not be
+// a true metric (the kd-tree's built-in squared Euclidean distance isn't
+// either), but it must agree with LowerBound: LowerBound(q, box) must
+// never exceed Distance(q, p) for any p inside box.
+type Metric interface {
+	Distance(a, b []float64) float64
+	LowerBound(query []float64, box Box) float64
+}
+
+// axisLowerBound folds the per-axis distance from query to box (zero on
+// any axis query already falls within) through accum, which combines the
+// per-axis terms the way the concrete metric combines them.
+func axisLowerBound(query []float64, box Box, accum func(sum, d float64) float64) float64 {
+	var sum float64
+	for i, q := range query {
+		var d float64
+		switch {
+		case q < box.Lo[i]:
+			d = box.Lo[i] - q
+		case q > box.Hi[i]:
+			d = q - box.Hi[i]
+		}
+		sum = accum(sum, d)
+	}
+	return sum
+}
+
+// EuclideanMetric is ordinary straight-line distance.
+type EuclideanMetric struct{}
+
+func (EuclideanMetric) Distance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func (EuclideanMetric) LowerBound(query []float64, box Box) float64 {
+	sum := axisLowerBound(query, box, func(sum, d float64) float64 { return sum + d*d })
+	return math.Sqrt(sum)
+}
+
+// ManhattanMetric sums absolute per-axis differences, producing
+// diamond-shaped (rectilinear) Voronoi cells.
+type ManhattanMetric struct{}
+
+func (ManhattanMetric) Distance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += math.Abs(a[i] - b[i])
+	}
+	return sum
+}
+
+func (ManhattanMetric) LowerBound(query []float64, box Box) float64 {
+	return axisLowerBound(query, box, func(sum, d float64) float64 { return sum + d })
+}
+
+// ChebyshevMetric takes the largest per-axis difference, producing
+// square Voronoi cells.
+type ChebyshevMetric struct{}
+
+func (ChebyshevMetric) Distance(a, b []float64) float64 {
+	var max float64
+	for i := range a {
+		if d := math.Abs(a[i] - b[i]); d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+func (ChebyshevMetric) LowerBound(query []float64, box Box) float64 {
+	return axisLowerBound(query, box, func(sum, d float64) float64 {
+		if d > sum {
+			return d
+		}
+		return sum
+	})
+}
+
+// MinkowskiMetric generalizes Euclidean (P=2), Manhattan (P=1) and, in
+// the limit, Chebyshev (P=large) distance.
+type MinkowskiMetric struct {
+	P float64
+}
+
+func (m MinkowskiMetric) Distance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += math.Pow(math.Abs(a[i]-b[i]), m.P)
+	}
+	return math.Pow(sum, 1/m.P)
+}
+
+func (m MinkowskiMetric) LowerBound(query []float64, box Box) float64 {
+	sum := axisLowerBound(query, box, func(sum, d float64) float64 { return sum + math.Pow(d, m.P) })
+	return math.Pow(sum, 1/m.P)
+}
+
+// GreatCircleMetric treats vec[0], vec[1] as latitude/longitude in
+// degrees and measures distance along the surface of a sphere of the
+// given radius, via the haversine formula. It only makes sense for
+// two-dimensional points.
+type GreatCircleMetric struct {
+	Radius float64
+}
+
+func (m GreatCircleMetric) Distance(a, b []float64) float64 {
+	lat1, lon1 := a[0]*math.Pi/180, a[1]*math.Pi/180
+	lat2, lon2 := b[0]*math.Pi/180, b[1]*math.Pi/180
+	dLat, dLon := lat2-lat1, lon2-lon1
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * m.Radius * math.Asin(math.Sqrt(h))
+}
+
+// LowerBound has no cheap axis-aligned form on a sphere, so it falls
+// back to zero: always valid, just not tight enough to prune anything.
+func (m GreatCircleMetric) LowerBound(query []float64, box Box) float64 {
+	return 0
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// segmentFor returns two points on l, far enough apart along its
+// direction to certainly span the w x h rectangle if l crosses it at
+// all, suitable as input to clipLiangBarsky.
+func segmentFor(l line, w, h int) (x1, y1, x2, y2 float64) {
+	denom := l.a*l.a + l.b*l.b
+	px, py := l.a*l.c/denom, l.b*l.c/denom
+	dx, dy := -l.b, l.a // direction along the line: perpendicular to its normal (a, b)
+	norm := math.Hypot(dx, dy)
+	scale := (math.Hypot(float64(w), float64(h)) + 1) / norm
+	dx, dy = dx*scale, dy*scale
+	return px - dx, py - dy, px + dx, py + dy
+}
+
+// clipLiangBarsky clips the segment (x1,y1)-(x2,y2) to [0,w-1] x
+// [0,h-1] using the Liang-Barsky parametric line-clipping algorithm.
+func clipLiangBarsky(x1, y1, x2, y2 float64, w, h int) (cx1, cy1, cx2, cy2 float64, ok bool) {
+	dx, dy := x2-x1, y2-y1
+	tMin, tMax := 0.0, 1.0
+	p := [4]float64{-dx, dx, -dy, dy}
+	q := [4]float64{x1, float64(w-1) - x1, y1, float64(h-1) - y1}
+	for i := 0; i < 4; i++ {
+		switch {
+		case p[i] == 0:
+			if q[i] < 0 {
+				return 0, 0, 0, 0, false
+			}
+		case p[i] < 0:
+			if t := q[i] / p[i]; t > tMax {
+				return 0, 0, 0, 0, false
+			} else if t > tMin {
+				tMin = t
+			}
+		default:
+			if t := q[i] / p[i]; t < tMin {
+				return 0, 0, 0, 0, false
+			} else if t < tMax {
+				tMax = t
+			}
+		}
+	}
+	return x1 + tMin*dx, y1 + tMin*dy, x1 + tMax*dx, y1 + tMax*dy, true
+}
+
+// blendPixel blends c into dst at (x, y) with the given coverage in
+// [0,1]. Like image/draw's autogenerated fast paths for each concrete
+// destination type, the hot cases (*image.RGBA, *image.NRGBA -- the
+// types glass actually rasterizes into) bypass the Image interface and
+// write Pix directly; anything else falls back to a generic draw.Over.
+func blendPixel(dst draw.Image, x, y int, c color.Color, coverage float64) {
+	if coverage <= 0 {
+		return
+	}
+	if coverage > 1 {
+		coverage = 1
+	}
+	switch d := dst.(type) {
+	case *image.RGBA:
+		blendRGBA(d, x, y, c, coverage)
+	case *image.NRGBA:
+		blendNRGBA(d, x, y, c, coverage)
+	default:
+		blendGeneric(dst, x, y, c, coverage)
+	}
+}
+
+func blendRGBA(d *image.RGBA, x, y int, c color.Color, coverage float64) {
+	if !(image.Point{X: x, Y: y}.In(d.Rect)) {
+		return
+	}
+	sr, sg, sb, sa := c.RGBA()
+	cov := uint32(coverage*0xffff + 0.5)
+	sr, sg, sb, sa = mulCov(sr, cov), mulCov(sg, cov), mulCov(sb, cov), mulCov(sa, cov)
+
+	i := d.PixOffset(x, y)
+	dr := uint32(d.Pix[i+0]) * 0x101
+	dg := uint32(d.Pix[i+1]) * 0x101
+	db := uint32(d.Pix[i+2]) * 0x101
+	da := uint32(d.Pix[i+3]) * 0x101
+
+	d.Pix[i+0] = uint8(over(sr, dr, sa) >> 8)
+	d.Pix[i+1] = uint8(over(sg, dg, sa) >> 8)
+	d.Pix[i+2] = uint8(over(sb, db, sa) >> 8)
+	d.Pix[i+3] = uint8(over(sa, da, sa) >> 8)
+}
+
+func mulCov(v, cov uint32) uint32 { return uint32(uint64(v) * uint64(cov) / 0xffff) }
+
+// over is the Porter-Duff "over" operator on premultiplied-alpha,
+// 16-bit-per-channel values, as used by *image.RGBA.
+func over(src, dst, srcA uint32) uint32 {
+	return src + uint32(uint64(dst)*uint64(0xffff-srcA)/0xffff)
+}
+
+func blendNRGBA(d *image.NRGBA, x, y int, c color.Color, coverage float64) {
+	if !(image.Point{X: x, Y: y}.In(d.Rect)) {
+		return
+	}
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	srcA := float64(nc.A) / 255 * coverage
+
+	i := d.PixOffset(x, y)
+	dstA := float64(d.Pix[i+3]) / 255
+	outA := srcA + dstA*(1-srcA)
+	blend := func(s, dv uint8) uint8 {
+		if outA == 0 {
+			return 0
+		}
+		return uint8((float64(s)*srcA + float64(dv)*dstA*(1-srcA)) / outA)
+	}
+	d.Pix[i+0] = blend(nc.R, d.Pix[i+0])
+	d.Pix[i+1] = blend(nc.G, d.Pix[i+1])
+	d.Pix[i+2] = blend(nc.B, d.Pix[i+2])
+	d.Pix[i+3] = uint8(outA * 255)
+}
+
+func blendGeneric(dst draw.Image, x, y int, c color.Color, coverage float64) {
+	if !(image.Point{X: x, Y: y}.In(dst.Bounds())) {
+		return
+	}
+	mask := image.NewUniform(color.Alpha{A: uint8(coverage*0xff + 0.5)})
+	draw.DrawMask(dst, image.Rect(x, y, x+1, y+1), image.NewUniform(c), image.Point{}, mask, image.Point{}, draw.Over)
+}
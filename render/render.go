@@ -0,0 +1,362 @@
+// Package render generates Voronoi-diagram scenes on demand: placing
+// sites, building a SpatialIndex, sweeping bisectors to find adjacent
+// cells, coloring them, and rasterizing (or exporting) the result.
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+)
+
+// Options fully describes a scene: everything Generate needs to
+// reproduce it deterministically from a seed.
+type Options struct {
+	NumPoints  int
+	Seed       int64
+	Width      int
+	Height     int
+	Metric     string
+	MinkowskiP float64
+	Palette    []color.Color
+	Grid       bool
+	Jitter     string // "uniform" (default) or "gaussian"
+	AA         bool   // anti-alias cell borders
+}
+
+const (
+	DefaultNumPoints = 20
+	DefaultWidth     = 58 * 40
+	DefaultHeight    = 20 * 40
+	DefaultMinkowski = 3
+
+	// MaxNumPoints bounds the O(n^2) bisector sweep in Generate; beyond
+	// this, a single request can tie up the goroutine handling it for an
+	// unreasonable amount of time.
+	MaxNumPoints = 100
+	// MaxWidth and MaxHeight bound the image.NewRGBA allocation and the
+	// w*h scans in fillCells/drawGrid, so a request can't ask for an
+	// image large enough to exhaust memory.
+	MaxWidth  = 4096
+	MaxHeight = 4096
+)
+
+// withDefaults fills in the zero-value fields of o with glass's
+// historical defaults, and clamps NumPoints/Width/Height to sane maxima
+// so a caller (notably the HTTP API in main.go, which passes query
+// parameters straight through) can't force an unbounded allocation or
+// O(n^2) sweep.
+func (o Options) withDefaults() Options {
+	if o.NumPoints <= 0 {
+		o.NumPoints = DefaultNumPoints
+	}
+	if o.NumPoints > MaxNumPoints {
+		o.NumPoints = MaxNumPoints
+	}
+	if o.Width <= 0 {
+		o.Width = DefaultWidth
+	}
+	if o.Width > MaxWidth {
+		o.Width = MaxWidth
+	}
+	if o.Height <= 0 {
+		o.Height = DefaultHeight
+	}
+	if o.Height > MaxHeight {
+		o.Height = MaxHeight
+	}
+	if o.Metric == "" {
+		o.Metric = "euclidean"
+	}
+	if o.MinkowskiP == 0 {
+		o.MinkowskiP = DefaultMinkowski
+	}
+	if o.Jitter == "" {
+		o.Jitter = "uniform"
+	}
+	return o
+}
+
+// Key canonicalizes Options into a cache key: equal Options always
+// produce equal keys, regardless of Palette slice identity.
+func (o Options) Key() string {
+	o = o.withDefaults()
+	return fmt.Sprintf("n=%d;seed=%d;w=%d;h=%d;metric=%s;p=%g;grid=%t;jitter=%s;aa=%t;palette=%s",
+		o.NumPoints, o.Seed, o.Width, o.Height, o.Metric, o.MinkowskiP, o.Grid, o.Jitter, o.AA, paletteKey(o.Palette))
+}
+
+func paletteKey(p []color.Color) string {
+	if len(p) == 0 {
+		return "default"
+	}
+	key := ""
+	for _, c := range p {
+		r, g, b, a := c.RGBA()
+		key += fmt.Sprintf("%04x%04x%04x%04x", r, g, b, a)
+	}
+	return key
+}
+
+// Scene is a fully computed Voronoi diagram.
+type Scene struct {
+	Opts   Options
+	Points []*point
+	adj    *adjList
+	Img    *image.RGBA
+}
+
+// Generate computes a Scene from scratch: placing sites, building the
+// requested SpatialIndex, sweeping every bisector to build the adjacency
+// graph and rasterize cell borders, coloring cells, and flood-filling
+// each cell with its site's color.
+func Generate(opts Options) *Scene {
+	opts = opts.withDefaults()
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	points := generatePoints(opts, rng)
+	idx := buildIndex(points, opts.Metric, opts.MinkowskiP)
+	im := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	al := newAdjList()
+
+	border := color.RGBA{0, 0, 0, 255}
+	for i, p := range points {
+		for _, nn := range points[i+1:] {
+			p.biscector(nn).drawNear(im, idx, opts.Width, opts.Height, border, al, opts.AA)
+		}
+	}
+
+	colorPoints(points, al, opts.Palette, rng)
+	fillCells(im, idx, opts.Width, opts.Height)
+	if opts.Grid {
+		drawGrid(im, opts.Width, opts.Height)
+	}
+
+	return &Scene{Opts: opts, Points: points, adj: al, Img: im}
+}
+
+func generatePoints(opts Options, rng *rand.Rand) []*point {
+	points := make([]*point, 0, opts.NumPoints)
+	for len(points) < opts.NumPoints {
+		var x, y float64
+		if opts.Jitter == "gaussian" {
+			x, y = clamp01(rng.NormFloat64()/6+0.5), clamp01(rng.NormFloat64()/6+0.5)
+		} else {
+			x, y = rng.Float64(), rng.Float64()
+		}
+		points = append(points, &point{
+			vec:   []float64{x * float64(opts.Width), y * float64(opts.Height)},
+			color: color.RGBA{0, 0, 0, 255},
+		})
+	}
+	return points
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
+
+func fillCells(im *image.RGBA, idx SpatialIndex, w, h int) {
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if _, _, _, a := im.At(x, y).RGBA(); a == 0 {
+				nn := idx.KNN(&point{vec: []float64{float64(x), float64(y)}}, 1)[0]
+				im.Set(x, y, nn.color)
+			}
+		}
+	}
+}
+
+func drawGrid(im *image.RGBA, w, h int) {
+	const cols, rows = 58, 20
+	grid := color.RGBA{128, 128, 128, 255}
+	xStep := w / cols
+	if xStep == 0 {
+		xStep = 1
+	}
+	yStep := h / rows
+	if yStep == 0 {
+		yStep = 1
+	}
+	for x := 0; x < w; x += xStep {
+		for y := 0; y < h; y++ {
+			im.Set(x, y, grid)
+		}
+	}
+	for y := 0; y < h; y += yStep {
+		for x := 0; x < w; x++ {
+			im.Set(x, y, grid)
+		}
+	}
+}
+
+// Site is a site's position and fill color, for client-side rendering.
+type Site struct {
+	X, Y  float64
+	Color string
+}
+
+// Sites returns every site's coordinates and hex fill color.
+func (s *Scene) Sites() []Site {
+	out := make([]Site, len(s.Points))
+	for i, p := range s.Points {
+		out[i] = Site{X: p.vec[0], Y: p.vec[1], Color: hexColor(p.color)}
+	}
+	return out
+}
+
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// AdjacencyPairs returns the scene's adjacency graph as pairs of indices
+// into Points/Sites(), each pair listed once. Combined with Sites() and
+// Opts, this is enough to reconstruct the scene via FromSaved without
+// redoing the O(n^2) bisector pass.
+func (s *Scene) AdjacencyPairs() [][2]int {
+	idx := make(map[xy]int, len(s.Points))
+	for i, p := range s.Points {
+		idx[p.xy()] = i
+	}
+	seen := map[[2]int]bool{}
+	var out [][2]int
+	for a, nbrs := range s.adj.l {
+		for b := range nbrs {
+			i, j := idx[a], idx[b]
+			if i > j {
+				i, j = j, i
+			}
+			key := [2]int{i, j}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// FromSaved rebuilds a Scene from previously computed site positions,
+// colors, and adjacency pairs (as returned by Sites and AdjacencyPairs),
+// skipping the O(n^2) bisector pass: it only rebuilds the SpatialIndex,
+// redraws the already-known boundary segments, and re-rasterizes each
+// cell's fill.
+func FromSaved(opts Options, sites []Site, pairs [][2]int) *Scene {
+	opts = opts.withDefaults()
+	points := make([]*point, len(sites))
+	for i, s := range sites {
+		points[i] = &point{vec: []float64{s.X, s.Y}, color: colorFromHex(s.Color)}
+	}
+	idx := buildIndex(points, opts.Metric, opts.MinkowskiP)
+	im := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	al := newAdjList()
+
+	border := color.RGBA{0, 0, 0, 255}
+	for _, pr := range pairs {
+		a, b := points[pr[0]], points[pr[1]]
+		al.link(a, b)
+		if x1, y1, x2, y2, ok := clippedSegment(a.biscector(b), opts.Width, opts.Height); ok {
+			drawSegment(im, x1, y1, x2, y2, border, opts.AA)
+		}
+	}
+
+	fillCells(im, idx, opts.Width, opts.Height)
+	if opts.Grid {
+		drawGrid(im, opts.Width, opts.Height)
+	}
+	return &Scene{Opts: opts, Points: points, adj: al, Img: im}
+}
+
+// drawSegment draws the straight line from (x1,y1) to (x2,y2) by
+// stepping along whichever axis it's longer in. When aa is true, each
+// step is blended across the two pixels straddling the minor axis
+// instead of flatly set, matching drawNear's AA path.
+func drawSegment(im draw.Image, x1, y1, x2, y2 float64, c color.Color, aa bool) {
+	steps := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1)))
+	if steps == 0 {
+		im.Set(int(x1), int(y1), c)
+		return
+	}
+	dx, dy := (x2-x1)/float64(steps), (y2-y1)/float64(steps)
+	x, y := x1, y1
+	for i := 0; i <= steps; i++ {
+		if aa {
+			drawAASample(im, x, y, dx, dy, c)
+		} else {
+			im.Set(int(x), int(y), c)
+		}
+		x += dx
+		y += dy
+	}
+}
+
+func colorFromHex(s string) color.Color {
+	var r, g, b uint8
+	fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b)
+	return color.RGBA{R: r, G: g, B: b, A: 255}
+}
+
+// Edge is one Voronoi cell-boundary segment, clipped to the image
+// rectangle, between two adjacent sites.
+type Edge struct {
+	X1, Y1, X2, Y2 float64
+}
+
+// Edges returns the clipped boundary segment for every pair of adjacent
+// sites in the scene's adjacency graph, each pair listed once.
+func (s *Scene) Edges() []Edge {
+	type pair struct{ a, b xy }
+	seen := map[pair]bool{}
+	var out []Edge
+	for a, nbrs := range s.adj.l {
+		for b := range nbrs {
+			key := pair{a, b}
+			if a.x > b.x || (a.x == b.x && a.y > b.y) {
+				key = pair{b, a}
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			l := a.point().biscector(b.point())
+			if x1, y1, x2, y2, ok := clippedSegment(l, s.Opts.Width, s.Opts.Height); ok {
+				out = append(out, Edge{x1, y1, x2, y2})
+			}
+		}
+	}
+	return out
+}
+
+// clippedSegment returns the two endpoints of l within the w x h image
+// rectangle.
+func clippedSegment(l line, w, h int) (x1, y1, x2, y2 float64, ok bool) {
+	if math.Abs(l.b) >= math.Abs(l.a) {
+		lo, hi := clipRangeX(l, w, h)
+		if lo >= hi {
+			return 0, 0, 0, 0, false
+		}
+		x1, x2 = float64(lo), float64(hi-1)
+		y1 = -(l.a/l.b)*x1 + l.c/l.b
+		y2 = -(l.a/l.b)*x2 + l.c/l.b
+		return x1, y1, x2, y2, true
+	}
+	lo, hi := clipRangeY(l, w, h)
+	if lo >= hi {
+		return 0, 0, 0, 0, false
+	}
+	y1, y2 = float64(lo), float64(hi-1)
+	x1 = -(l.b/l.a)*y1 + l.c/l.a
+	x2 = -(l.b/l.a)*y2 + l.c/l.a
+	return x1, y1, x2, y2, true
+}
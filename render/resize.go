@@ -0,0 +1,63 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Resize resamples src to exactly w x h using bilinear interpolation,
+// the same kind of proper resampling an icon-resizing endpoint would use
+// rather than nearest-neighbor decimation.
+func Resize(src *image.RGBA, w, h int) *image.RGBA {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy := (float64(y)+0.5)*float64(sh)/float64(h) - 0.5
+		for x := 0; x < w; x++ {
+			sx := (float64(x)+0.5)*float64(sw)/float64(w) - 0.5
+			dst.Set(x, y, bilinear(src, sx, sy))
+		}
+	}
+	return dst
+}
+
+func bilinear(src *image.RGBA, x, y float64) color.Color {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	r00, g00, b00, a00 := clampedAt(src, x0, y0).RGBA()
+	r10, g10, b10, a10 := clampedAt(src, x0+1, y0).RGBA()
+	r01, g01, b01, a01 := clampedAt(src, x0, y0+1).RGBA()
+	r11, g11, b11, a11 := clampedAt(src, x0+1, y0+1).RGBA()
+
+	blend := func(c00, c10, c01, c11 uint32) uint8 {
+		top := lerp(float64(c00), float64(c10), fx)
+		bot := lerp(float64(c01), float64(c11), fx)
+		return uint8(lerp(top, bot, fy) / 257) // 16-bit channel back to 8-bit
+	}
+	return color.RGBA{
+		R: blend(r00, r10, r01, r11),
+		G: blend(g00, g10, g01, g11),
+		B: blend(b00, b10, b01, b11),
+		A: blend(a00, a10, a01, a11),
+	}
+}
+
+func lerp(a, b, t float64) float64 { return a + (b-a)*t }
+
+func clampedAt(src *image.RGBA, x, y int) color.Color {
+	b := src.Bounds()
+	if x < b.Min.X {
+		x = b.Min.X
+	} else if x >= b.Max.X {
+		x = b.Max.X - 1
+	}
+	if y < b.Min.Y {
+		y = b.Min.Y
+	} else if y >= b.Max.Y {
+		y = b.Max.Y - 1
+	}
+	return src.At(x, y)
+}
@@ -0,0 +1,191 @@
+package render
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// rtreeFanout is the maximum number of children per R-tree node.
+const rtreeFanout = 8
+
+// RTree is an R-tree spatial index, bulk-loaded once via STR packing
+// rather than built incrementally, since glass's point set is static for
+// the life of a render.
+type RTree struct {
+	root *rtNode
+}
+
+type rtNode struct {
+	box      Box
+	children []*rtNode
+	leaf     *point // non-nil on leaf nodes
+}
+
+// NewRTree bulk-loads an R-tree over points using sort-tile-recursive
+// (STR) packing.
+func NewRTree(points []*point) *RTree {
+	if len(points) == 0 {
+		return &RTree{}
+	}
+	leaves := make([]*rtNode, len(points))
+	for i, p := range points {
+		leaves[i] = &rtNode{
+			box:  Box{Lo: []float64{p.vec[0], p.vec[1]}, Hi: []float64{p.vec[0], p.vec[1]}},
+			leaf: p,
+		}
+	}
+	return &RTree{root: strPack(leaves)}
+}
+
+// strPack groups nodes into a balanced tree of rtreeFanout-way nodes by
+// sorting into vertical slices along x sized to make each slice roughly
+// square, then sorting each slice along y and chunking it into leaves of
+// rtreeFanout nodes, recursing until a single root remains.
+func strPack(nodes []*rtNode) *rtNode {
+	if len(nodes) <= rtreeFanout {
+		return wrapNodes(nodes)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return boxCenter(nodes[i].box, 0) < boxCenter(nodes[j].box, 0) })
+
+	leafGroups := (len(nodes) + rtreeFanout - 1) / rtreeFanout
+	numSlices := int(math.Ceil(math.Sqrt(float64(leafGroups))))
+	sliceSize := int(math.Ceil(float64(len(nodes)) / float64(numSlices)))
+
+	var level []*rtNode
+	for i := 0; i < len(nodes); i += sliceSize {
+		end := i + sliceSize
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+		slice := nodes[i:end]
+		sort.Slice(slice, func(a, b int) bool { return boxCenter(slice[a].box, 1) < boxCenter(slice[b].box, 1) })
+		for j := 0; j < len(slice); j += rtreeFanout {
+			k := j + rtreeFanout
+			if k > len(slice) {
+				k = len(slice)
+			}
+			level = append(level, wrapNodes(slice[j:k]))
+		}
+	}
+	if len(level) == 1 {
+		return level[0]
+	}
+	return strPack(level)
+}
+
+func wrapNodes(nodes []*rtNode) *rtNode {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	box := nodes[0].box
+	for _, n := range nodes[1:] {
+		box = unionBox(box, n.box)
+	}
+	return &rtNode{box: box, children: nodes}
+}
+
+func unionBox(a, b Box) Box {
+	lo := make([]float64, len(a.Lo))
+	hi := make([]float64, len(a.Hi))
+	for i := range a.Lo {
+		lo[i] = math.Min(a.Lo[i], b.Lo[i])
+		hi[i] = math.Max(a.Hi[i], b.Hi[i])
+	}
+	return Box{Lo: lo, Hi: hi}
+}
+
+func boxCenter(b Box, dim int) float64 { return (b.Lo[dim] + b.Hi[dim]) / 2 }
+
+// boxDistPoint is the squared Euclidean distance from p to the nearest
+// point of box, or 0 if p is inside box.
+func boxDistPoint(box Box, p []float64) float64 {
+	var sum float64
+	for i, v := range p {
+		var d float64
+		switch {
+		case v < box.Lo[i]:
+			d = box.Lo[i] - v
+		case v > box.Hi[i]:
+			d = v - box.Hi[i]
+		}
+		sum += d * d
+	}
+	return sum
+}
+
+type rtItem struct {
+	node *rtNode
+	dist float64
+}
+
+// rtHeap is a min-heap on dist, the priority queue that drives best-first
+// traversal.
+type rtHeap []rtItem
+
+func (h rtHeap) Len() int            { return len(h) }
+func (h rtHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h rtHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *rtHeap) Push(x interface{}) { *h = append(*h, x.(rtItem)) }
+func (h *rtHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// KNN returns the k points nearest q, implementing SpatialIndex via a
+// best-first traversal: push the root, then repeatedly pop the
+// least-boxDistPoint entry, emitting it if it's a leaf or expanding its
+// children if not, until k leaves have been emitted.
+// Metric reports the Euclidean distance the R-tree's bounding boxes are
+// built from, implementing SpatialIndex.
+func (t *RTree) Metric() Metric { return EuclideanMetric{} }
+
+func (t *RTree) KNN(q *point, k int) []*point {
+	if t.root == nil || k <= 0 {
+		return nil
+	}
+	h := &rtHeap{{node: t.root, dist: boxDistPoint(t.root.box, q.vec)}}
+	heap.Init(h)
+
+	var out []*point
+	for h.Len() > 0 && len(out) < k {
+		it := heap.Pop(h).(rtItem)
+		if it.node.leaf != nil {
+			out = append(out, it.node.leaf)
+			continue
+		}
+		for _, c := range it.node.children {
+			heap.Push(h, rtItem{node: c, dist: boxDistPoint(c.box, q.vec)})
+		}
+	}
+	return out
+}
+
+// RangeQuery returns every indexed point within radius of center,
+// descending only into subtrees whose bounding box comes within radius.
+func (t *RTree) RangeQuery(center *point, radius float64) []*point {
+	if t.root == nil {
+		return nil
+	}
+	var out []*point
+	r2 := radius * radius
+	var walk func(n *rtNode)
+	walk = func(n *rtNode) {
+		if boxDistPoint(n.box, center.vec) > r2 {
+			return
+		}
+		if n.leaf != nil {
+			out = append(out, n.leaf)
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(t.root)
+	return out
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// rangeQueryRadius bounds the neighborhood drawNear caches around the
+// current scan position: as long as the scan hasn't moved more than half
+// this far since the last query, the cached candidates are trusted
+// instead of re-querying idx.
+const rangeQueryRadius = 8.0
+
+// drawNear sweeps only the portion of l clipped (via Liang-Barsky) to the
+// w x h image rectangle, stepping along an incremental vector rather than
+// recomputing y from x (or vice versa) at each pixel. At each step it
+// keeps the two nearest sites by refreshing a small local neighborhood
+// via idx.RangeQuery rather than running a fresh tree descent from the
+// root at every pixel. When aa is true, border pixels are blended with
+// Wu-style fractional coverage instead of flatly set.
+func (l line) drawNear(im draw.Image, idx SpatialIndex, w, h int, c color.Color, al *adjList, aa bool) {
+	sx1, sy1, sx2, sy2 := segmentFor(l, w, h)
+	x1, y1, x2, y2, ok := clipLiangBarsky(sx1, sy1, sx2, sy2, w, h)
+	if !ok {
+		return
+	}
+
+	dx, dy := x2-x1, y2-y1
+	steps := int(math.Max(math.Abs(dx), math.Abs(dy)))
+	if steps == 0 {
+		steps = 1
+	}
+	stepX, stepY := dx/float64(steps), dy/float64(steps)
+
+	metric := idx.Metric()
+	var cached []*point
+	var cachedAt xy
+	nearestTwo := func(n *point) (*point, *point, bool) {
+		if cached == nil || metric.Distance(n.vec, cachedAt.point().vec) > rangeQueryRadius/2 {
+			cached = idx.RangeQuery(n, rangeQueryRadius)
+			cachedAt = n.xy()
+			if len(cached) < 2 {
+				cached = idx.KNN(n, 2)
+			}
+		}
+		if len(cached) < 2 {
+			return nil, nil, false
+		}
+		var n0, n1 *point
+		d0, d1 := math.Inf(1), math.Inf(1)
+		for _, p := range cached {
+			d := metric.Distance(n.vec, p.vec)
+			switch {
+			case d < d0:
+				n0, n1, d0, d1 = p, n0, d, d0
+			case d < d1:
+				n1, d1 = p, d
+			}
+		}
+		return n0, n1, n1 != nil
+	}
+
+	x, y := x1, y1
+	for i := 0; i <= steps; i++ {
+		n := &point{vec: []float64{x, y}}
+		if n0, n1, ok := nearestTwo(n); ok && math.Abs(metric.Distance(n.vec, n0.vec)-metric.Distance(n.vec, n1.vec)) < 1 {
+			al.link(n0, n1)
+			if aa {
+				drawAASample(im, x, y, stepX, stepY, c)
+			} else {
+				im.Set(int(math.Round(x)), int(math.Round(y)), c)
+			}
+		}
+		x += stepX
+		y += stepY
+	}
+}
+
+// drawAASample blends c at (x, y) into im, splitting fractional coverage
+// across the two pixels straddling the minor axis (whichever of
+// stepX/stepY is smaller in magnitude), Wu-style.
+func drawAASample(im draw.Image, x, y, stepX, stepY float64, c color.Color) {
+	if math.Abs(stepX) >= math.Abs(stepY) {
+		yFloor := math.Floor(y)
+		frac := y - yFloor
+		blendPixel(im, int(math.Round(x)), int(yFloor), c, 1-frac)
+		blendPixel(im, int(math.Round(x)), int(yFloor)+1, c, frac)
+		return
+	}
+	xFloor := math.Floor(x)
+	frac := x - xFloor
+	blendPixel(im, int(xFloor), int(math.Round(y)), c, 1-frac)
+	blendPixel(im, int(xFloor)+1, int(math.Round(y)), c, frac)
+}
+
+// clipRangeX returns the [lo, hi) range of x for which y = -(a/b)x + c/b
+// falls inside [0, h), restricted to [0, w).
+func clipRangeX(l line, w, h int) (int, int) {
+	if l.b == 0 {
+		return 0, 0
+	}
+	slope, intercept := -l.a/l.b, l.c/l.b
+	if slope == 0 {
+		if intercept < 0 || intercept >= float64(h) {
+			return 0, 0
+		}
+		return 0, w
+	}
+	x0, x1 := (0-intercept)/slope, (float64(h)-intercept)/slope
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	lo := int(math.Max(0, math.Ceil(x0)))
+	hi := int(math.Min(float64(w), math.Floor(x1)+1))
+	if lo > hi {
+		lo = hi
+	}
+	return lo, hi
+}
+
+// clipRangeY returns the [lo, hi) range of y for which x = -(b/a)y + c/a
+// falls inside [0, w), restricted to [0, h).
+func clipRangeY(l line, w, h int) (int, int) {
+	if l.a == 0 {
+		return 0, 0
+	}
+	slope, intercept := -l.b/l.a, l.c/l.a
+	if slope == 0 {
+		if intercept < 0 || intercept >= float64(w) {
+			return 0, 0
+		}
+		return 0, h
+	}
+	y0, y1 := (0-intercept)/slope, (float64(w)-intercept)/slope
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	lo := int(math.Max(0, math.Ceil(y0)))
+	hi := int(math.Min(float64(h), math.Floor(y1)+1))
+	if lo > hi {
+		lo = hi
+	}
+	return lo, hi
+}
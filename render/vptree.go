@@ -0,0 +1,205 @@
+package render
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// VPTree is a vantage-point tree: a nearest-neighbor index over a metric
+// space that needs only a Metric, not axis-aligned coordinates, so it
+// can serve metrics (Manhattan, Chebyshev, great-circle, ...) that don't
+// decompose cleanly into per-axis bounds the way go-kdtree requires.
+type VPTree struct {
+	metric Metric
+	root   *vpNode
+}
+
+type vpNode struct {
+	point        *point
+	mu           float64 // median distance from point to everything in outer
+	inner, outer *vpNode
+}
+
+// NewVPTree builds a vantage-point tree over points under metric.
+func NewVPTree(points []*point, metric Metric) *VPTree {
+	items := append([]*point(nil), points...)
+	return &VPTree{metric: metric, root: buildVPNode(items, metric)}
+}
+
+// Metric returns the metric the tree was built with, implementing
+// SpatialIndex.
+func (t *VPTree) Metric() Metric { return t.metric }
+
+// buildVPNode recursively picks a vantage point, splits the remaining
+// points at the median distance from it into inner (<= mu) and outer
+// (> mu) subtrees, and recurses. It mutates pts in place.
+func buildVPNode(pts []*point, metric Metric) *vpNode {
+	if len(pts) == 0 {
+		return nil
+	}
+	vi := pickVantage(pts, metric)
+	pts[vi], pts[len(pts)-1] = pts[len(pts)-1], pts[vi]
+	vp := pts[len(pts)-1]
+	rest := pts[:len(pts)-1]
+	if len(rest) == 0 {
+		return &vpNode{point: vp}
+	}
+
+	dists := make([]float64, len(rest))
+	for i, p := range rest {
+		dists[i] = metric.Distance(vp.vec, p.vec)
+	}
+	mu := median(append([]float64(nil), dists...))
+
+	var inner, outer []*point
+	for i, p := range rest {
+		if dists[i] <= mu {
+			inner = append(inner, p)
+		} else {
+			outer = append(outer, p)
+		}
+	}
+	return &vpNode{
+		point: vp,
+		mu:    mu,
+		inner: buildVPNode(inner, metric),
+		outer: buildVPNode(outer, metric),
+	}
+}
+
+// pickVantage samples a small set of candidates and keeps the one with
+// the largest distance to another random sample, which tends to produce
+// more balanced splits than picking a vantage point purely at random.
+func pickVantage(pts []*point, metric Metric) int {
+	if len(pts) <= 2 {
+		return 0
+	}
+	const samples = 5
+	best, bestSpread := 0, -1.0
+	for c := 0; c < samples; c++ {
+		ci := rand.Intn(len(pts))
+		var spread float64
+		for s := 0; s < samples; s++ {
+			si := rand.Intn(len(pts))
+			if d := metric.Distance(pts[ci].vec, pts[si].vec); d > spread {
+				spread = d
+			}
+		}
+		if spread > bestSpread {
+			best, bestSpread = ci, spread
+		}
+	}
+	return best
+}
+
+func median(d []float64) float64 {
+	sort.Float64s(d)
+	n := len(d)
+	if n%2 == 1 {
+		return d[n/2]
+	}
+	return (d[n/2-1] + d[n/2]) / 2
+}
+
+// KNN returns the k points nearest q, implementing SpatialIndex.
+func (t *VPTree) KNN(q *point, k int) []*point {
+	if t.root == nil || k <= 0 {
+		return nil
+	}
+	cands := &vpHeap{}
+	heap.Init(cands)
+	searchVP(t.root, q, k, t.metric, cands)
+
+	out := make([]*point, cands.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(cands).(vpItem).p
+	}
+	return out
+}
+
+// searchVP descends the side of n containing q first, then uses the
+// triangle inequality |d(q, v) - mu| as a lower bound on how close the
+// far side could possibly be to decide whether it must be visited too.
+func searchVP(n *vpNode, q *point, k int, metric Metric, cands *vpHeap) {
+	if n == nil {
+		return
+	}
+	d := metric.Distance(n.point.vec, q.vec)
+	if cands.Len() < k {
+		heap.Push(cands, vpItem{n.point, d})
+	} else if d < (*cands)[0].d {
+		heap.Pop(cands)
+		heap.Push(cands, vpItem{n.point, d})
+	}
+	if n.inner == nil && n.outer == nil {
+		return
+	}
+
+	near, far := n.inner, n.outer
+	if d > n.mu {
+		near, far = n.outer, n.inner
+	}
+	searchVP(near, q, k, metric, cands)
+
+	worst := math.Inf(1)
+	if cands.Len() == k {
+		worst = (*cands)[0].d
+	}
+	if math.Abs(d-n.mu) < worst {
+		searchVP(far, q, k, metric, cands)
+	}
+}
+
+// RangeQuery returns every indexed point within radius of center,
+// pruning subtrees the triangle inequality rules out entirely.
+func (t *VPTree) RangeQuery(center *point, radius float64) []*point {
+	if t.root == nil {
+		return nil
+	}
+	var out []*point
+	var walk func(n *vpNode)
+	walk = func(n *vpNode) {
+		if n == nil {
+			return
+		}
+		d := t.metric.Distance(n.point.vec, center.vec)
+		if d <= radius {
+			out = append(out, n.point)
+		}
+		if n.inner == nil && n.outer == nil {
+			return
+		}
+		if d-radius <= n.mu {
+			walk(n.inner)
+		}
+		if d+radius > n.mu {
+			walk(n.outer)
+		}
+	}
+	walk(t.root)
+	return out
+}
+
+// vpItem is a candidate neighbor with its distance to the query point.
+type vpItem struct {
+	p *point
+	d float64
+}
+
+// vpHeap is a max-heap on distance, so the worst of the current best-k
+// candidates is always at the top and can be evicted in O(log k).
+type vpHeap []vpItem
+
+func (h vpHeap) Len() int            { return len(h) }
+func (h vpHeap) Less(i, j int) bool  { return h[i].d > h[j].d }
+func (h vpHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *vpHeap) Push(x interface{}) { *h = append(*h, x.(vpItem)) }
+func (h *vpHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,53 @@
+// Package scene implements a compact, snappy-compressed on-disk format
+// for a computed render.Scene: site coordinates, the adjacency graph,
+// per-site color assignments, image bounds, metric, and RNG seed. This
+// lets seeds be shared across machines even if the RNG or SpatialIndex
+// implementation changes, and lets a scene be re-rasterized at a
+// different resolution without recomputing the adjacency graph.
+package scene
+
+import (
+	"encoding/gob"
+	"image/color"
+	"io"
+
+	"github.com/golang/snappy"
+
+	"github.com/kurin/glass/render"
+)
+
+func init() {
+	gob.Register(color.RGBA{})
+}
+
+// fileFormat is the gob-encoded payload Save writes and Load reads,
+// snappy-framed on the wire.
+type fileFormat struct {
+	Opts  render.Options
+	Sites []render.Site
+	Pairs [][2]int
+}
+
+// Save writes s to w as a snappy-framed, gob-encoded scene.
+func Save(w io.Writer, s *render.Scene) error {
+	sw := snappy.NewBufferedWriter(w)
+	if err := gob.NewEncoder(sw).Encode(fileFormat{
+		Opts:  s.Opts,
+		Sites: s.Sites(),
+		Pairs: s.AdjacencyPairs(),
+	}); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// Load reads a scene previously written by Save and rebuilds it via
+// render.FromSaved, which skips the O(n^2) bisector pass and only
+// re-rasterizes the image.
+func Load(r io.Reader) (*render.Scene, error) {
+	var ff fileFormat
+	if err := gob.NewDecoder(snappy.NewReader(r)).Decode(&ff); err != nil {
+		return nil, err
+	}
+	return render.FromSaved(ff.Opts, ff.Sites, ff.Pairs), nil
+}